@@ -0,0 +1,28 @@
+package policy
+
+// denySentinel marks a deny() result inside the plain-string error channel
+// cel-go's Program.Eval gives us for runtime errors. It's a NUL-delimited,
+// namespaced tag rather than a plain "deny:" prefix so it can't collide
+// with an expression author's own error text.
+const denySentinel = "\x00trial-cel/deny\x00"
+
+// Decision is the normalized result of evaluating a Policy: either the
+// expression allowed the PVC (Score carries its raw int result), or it
+// called deny(), in which case DenyMessage explains why.
+type Decision struct {
+	Allowed     bool
+	DenyMessage string
+	Score       int64
+	Cost        uint64
+}
+
+// DenyError is returned by Evaluate when the expression calls deny(msg),
+// so callers can errors.As instead of string-matching err.Error() for the
+// "deny:" prefix.
+type DenyError struct {
+	Message string
+}
+
+func (e *DenyError) Error() string {
+	return denySentinel + e.Message
+}
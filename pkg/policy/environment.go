@@ -0,0 +1,20 @@
+package policy
+
+// EnvironmentVersion pins which CEL function/library set a Policy is
+// compiled against, mirroring k8s.io/apiserver/pkg/cel/environment: rules
+// written against EnvironmentStable keep working across upgrades even as
+// new, not-yet-stable bindings are added under EnvironmentBeta.
+type EnvironmentVersion string
+
+const (
+	// EnvironmentStable is the default: only bindings that are safe to
+	// keep compatible across releases.
+	EnvironmentStable EnvironmentVersion = "stable"
+	// EnvironmentBeta additionally exposes bindings that may still change
+	// shape or be removed.
+	EnvironmentBeta EnvironmentVersion = "beta"
+)
+
+func (v EnvironmentVersion) includesBeta() bool {
+	return v == EnvironmentBeta
+}
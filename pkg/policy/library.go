@@ -0,0 +1,10 @@
+package policy
+
+import "github.com/google/cel-go/cel"
+
+// betaLibraries returns the cel.EnvOptions only available under
+// EnvironmentBeta. Empty for now; domain-specific libraries register here
+// as they're added.
+func betaLibraries() []cel.EnvOption {
+	return nil
+}
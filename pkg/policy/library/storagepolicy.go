@@ -0,0 +1,154 @@
+// Package library provides domain-specific CEL bindings for storage
+// policy expressions, in the same spirit as k8s.io/apiserver/pkg/cel/library's
+// Quantity()/URLs()/Regex(): a single cel.EnvOption a caller opts into.
+package library
+
+import (
+	"regexp"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// StoragePolicy registers the storage-specific helper functions PVC
+// resize policies tend to need:
+//
+//	pvc.usageRatio(stats)         byte usage as a fraction of capacity
+//	pvc.inodeUsageRatio(stats)    inode usage as a fraction of capacity
+//	pvc.labelMatches(key, re)     true if pvc.Labels[key] matches the regex
+//	sc.hasParameter(key)          true if the StorageClass sets parameter key
+//	quantity.asBytes()            resource.Quantity as an integer byte count
+//	quantity.asMillis()           resource.Quantity as milli-units
+//
+// CEL has no closures over the evaluation's other variables, so
+// usageRatio/inodeUsageRatio take stats explicitly rather than reading it
+// off the pvc receiver.
+func StoragePolicy() cel.EnvOption {
+	return cel.Lib(storagePolicyLib{})
+}
+
+type storagePolicyLib struct{}
+
+func (storagePolicyLib) LibraryName() string {
+	return "trial-cel.llamerada.jp/storagepolicy"
+}
+
+func (storagePolicyLib) CompileOptions() []cel.EnvOption {
+	pvcType := cel.ObjectType("v1.PersistentVolumeClaim")
+	scType := cel.ObjectType("v1.StorageClass")
+	statsType := cel.ObjectType("policy.VolumeStats")
+	quantityType := cel.ObjectType("resource.Quantity")
+
+	return []cel.EnvOption{
+		cel.Function("usageRatio",
+			cel.MemberOverload("pvc_usageRatio_stats",
+				[]*cel.Type{pvcType, statsType}, cel.DoubleType,
+				cel.BinaryBinding(pvcUsageRatio))),
+		cel.Function("inodeUsageRatio",
+			cel.MemberOverload("pvc_inodeUsageRatio_stats",
+				[]*cel.Type{pvcType, statsType}, cel.DoubleType,
+				cel.BinaryBinding(pvcInodeUsageRatio))),
+		cel.Function("labelMatches",
+			cel.MemberOverload("pvc_labelMatches_string_string",
+				[]*cel.Type{pvcType, cel.StringType, cel.StringType}, cel.BoolType,
+				cel.FunctionBinding(pvcLabelMatches))),
+		cel.Function("hasParameter",
+			cel.MemberOverload("sc_hasParameter_string",
+				[]*cel.Type{scType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(scHasParameter))),
+		cel.Function("asBytes",
+			cel.MemberOverload("quantity_asBytes",
+				[]*cel.Type{quantityType}, cel.IntType,
+				cel.UnaryBinding(quantityAsBytes))),
+		cel.Function("asMillis",
+			cel.MemberOverload("quantity_asMillis",
+				[]*cel.Type{quantityType}, cel.IntType,
+				cel.UnaryBinding(quantityAsMillis))),
+	}
+}
+
+func (storagePolicyLib) ProgramOptions() []cel.ProgramOption {
+	return nil
+}
+
+func pvcUsageRatio(_, statsArg ref.Val) ref.Val {
+	stats, ok := statsArg.Value().(*policy.VolumeStats)
+	if !ok {
+		return types.NewErr("usageRatio requires VolumeStats as an argument")
+	}
+	return types.Double(ratio(stats.CapacityBytes-stats.AvailableBytes, stats.CapacityBytes))
+}
+
+func pvcInodeUsageRatio(_, statsArg ref.Val) ref.Val {
+	stats, ok := statsArg.Value().(*policy.VolumeStats)
+	if !ok {
+		return types.NewErr("inodeUsageRatio requires VolumeStats as an argument")
+	}
+	return types.Double(ratio(stats.CapacityInodeSize-stats.AvailableInodeSize, stats.CapacityInodeSize))
+}
+
+func ratio(used, capacity int64) float64 {
+	if capacity <= 0 {
+		return 0
+	}
+	return float64(used) / float64(capacity)
+}
+
+func pvcLabelMatches(args ...ref.Val) ref.Val {
+	if len(args) != 3 {
+		return types.NewErr("labelMatches requires (pvc, key, pattern)")
+	}
+	pvc, ok := args[0].Value().(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return types.NewErr("labelMatches requires PersistentVolumeClaim as the receiver")
+	}
+	key, ok := args[1].Value().(string)
+	if !ok {
+		return types.NewErr("labelMatches requires a string label key")
+	}
+	pattern, ok := args[2].Value().(string)
+	if !ok {
+		return types.NewErr("labelMatches requires a string pattern")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return types.NewErr("labelMatches: %v", err)
+	}
+	return types.Bool(re.MatchString(pvc.Labels[key]))
+}
+
+func scHasParameter(scArg, keyArg ref.Val) ref.Val {
+	sc, ok := scArg.Value().(*storagev1.StorageClass)
+	if !ok {
+		return types.NewErr("hasParameter requires StorageClass as the receiver")
+	}
+	key, ok := keyArg.Value().(string)
+	if !ok {
+		return types.NewErr("hasParameter requires a string parameter key")
+	}
+	_, ok = sc.Parameters[key]
+	return types.Bool(ok)
+}
+
+func quantityAsBytes(arg ref.Val) ref.Val {
+	q, ok := arg.Value().(*resource.Quantity)
+	if !ok {
+		return types.NewErr("asBytes requires resource.Quantity as the receiver")
+	}
+	return types.Int(q.Value())
+}
+
+func quantityAsMillis(arg ref.Val) ref.Val {
+	q, ok := arg.Value().(*resource.Quantity)
+	if !ok {
+		return types.NewErr("asMillis requires resource.Quantity as the receiver")
+	}
+	return types.Int(q.MilliValue())
+}
@@ -0,0 +1,129 @@
+package library
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// eval compiles and runs a single StoragePolicy expression against the
+// given variables, exercising the bindings the same way Policy.Evaluate
+// does: through a real cel.Env rather than by hand-building ref.Vals.
+func eval(t *testing.T, expr string, vars map[string]interface{}) ref.Val {
+	t.Helper()
+
+	env, err := cel.NewEnv(
+		ext.NativeTypes(
+			reflect.TypeOf(&corev1.PersistentVolumeClaim{}),
+			reflect.TypeOf(&storagev1.StorageClass{}),
+			reflect.TypeOf(&policy.VolumeStats{}),
+			reflect.TypeOf(&resource.Quantity{}),
+			ext.ParseStructTag("json"),
+		),
+		cel.Variable("pvc", cel.ObjectType("v1.PersistentVolumeClaim")),
+		cel.Variable("sc", cel.ObjectType("v1.StorageClass")),
+		cel.Variable("stats", cel.ObjectType("policy.VolumeStats")),
+		cel.Variable("quantity", cel.ObjectType("resource.Quantity")),
+		StoragePolicy(),
+	)
+	if err != nil {
+		t.Fatalf("NewEnv: %v", err)
+	}
+
+	ast, iss := env.Compile(expr)
+	if err := iss.Err(); err != nil {
+		t.Fatalf("Compile(%q): %v", expr, err)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("Program(%q): %v", expr, err)
+	}
+
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		t.Fatalf("Eval(%q): %v", expr, err)
+	}
+	return out
+}
+
+func TestUsageRatio(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	stats := &policy.VolumeStats{CapacityBytes: 100, AvailableBytes: 25}
+	got := eval(t, "pvc.usageRatio(stats)", map[string]interface{}{"pvc": pvc, "stats": stats})
+	if d, ok := got.Value().(float64); !ok || d != 0.75 {
+		t.Errorf("usageRatio = %v, want 0.75", got)
+	}
+}
+
+func TestUsageRatio_ZeroCapacity(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	stats := &policy.VolumeStats{}
+	got := eval(t, "pvc.usageRatio(stats)", map[string]interface{}{"pvc": pvc, "stats": stats})
+	if d, ok := got.Value().(float64); !ok || d != 0 {
+		t.Errorf("usageRatio with zero capacity = %v, want 0", got)
+	}
+}
+
+func TestInodeUsageRatio(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	stats := &policy.VolumeStats{CapacityInodeSize: 100, AvailableInodeSize: 40}
+	got := eval(t, "pvc.inodeUsageRatio(stats)", map[string]interface{}{"pvc": pvc, "stats": stats})
+	if d, ok := got.Value().(float64); !ok || d != 0.6 {
+		t.Errorf("inodeUsageRatio = %v, want 0.6", got)
+	}
+}
+
+func TestLabelMatches(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Labels = map[string]string{"tier": "gold-1"}
+
+	cases := []struct {
+		name    string
+		pattern string
+		want    bool
+	}{
+		{name: "matches", pattern: "^gold-", want: true},
+		{name: "does not match", pattern: "^silver-", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr := "pvc.labelMatches('tier', '" + tc.pattern + "')"
+			got := eval(t, expr, map[string]interface{}{"pvc": pvc})
+			if b, ok := got.Value().(bool); !ok || b != tc.want {
+				t.Errorf("labelMatches(%q) = %v, want %v", tc.pattern, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHasParameter(t *testing.T) {
+	sc := &storagev1.StorageClass{Parameters: map[string]string{"type": "ssd"}}
+
+	if got := eval(t, "sc.hasParameter('type')", map[string]interface{}{"sc": sc}); got.Value() != true {
+		t.Errorf("hasParameter(type) = %v, want true", got)
+	}
+	if got := eval(t, "sc.hasParameter('missing')", map[string]interface{}{"sc": sc}); got.Value() != false {
+		t.Errorf("hasParameter(missing) = %v, want false", got)
+	}
+}
+
+func TestQuantityAsBytesAndMillis(t *testing.T) {
+	q := resource.MustParse("1500m")
+
+	if got := eval(t, "quantity.asBytes()", map[string]interface{}{"quantity": &q}); got.Value() != int64(2) {
+		t.Errorf("asBytes(1500m) = %v, want 2", got)
+	}
+	if got := eval(t, "quantity.asMillis()", map[string]interface{}{"quantity": &q}); got.Value() != int64(1500) {
+		t.Errorf("asMillis(1500m) = %v, want 1500", got)
+	}
+}
@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apiserver/pkg/cel/library"
+)
+
+// Policy is a CEL expression compiled against the pvc/sc/stats/quota environment,
+// ready to be Evaluated against live objects.
+type Policy struct {
+	ast *cel.Ast
+	prg cel.Program
+}
+
+type config struct {
+	environment EnvironmentVersion
+	costLimit   uint64
+	extra       []cel.EnvOption
+}
+
+// Option configures Compile.
+type Option func(*config)
+
+// WithEnvironmentVersion pins the base environment a Policy is compiled
+// against. Defaults to EnvironmentStable.
+func WithEnvironmentVersion(v EnvironmentVersion) Option {
+	return func(c *config) { c.environment = v }
+}
+
+// WithCostLimit overrides the evaluation cost limit. Defaults to 1000.
+func WithCostLimit(limit uint64) Option {
+	return func(c *config) { c.costLimit = limit }
+}
+
+// WithEnvOption registers an additional cel.EnvOption, such as a
+// domain-specific library (e.g. pkg/policy/library.StoragePolicy()), on
+// top of the base pvc/sc/stats/quota environment.
+func WithEnvOption(opt cel.EnvOption) Option {
+	return func(c *config) { c.extra = append(c.extra, opt) }
+}
+
+// Compile builds the CEL environment and compiles src into a Policy. src
+// must be an expression returning int (0 for allow, non-zero as a score)
+// or calling deny(string).
+func Compile(src string, opts ...Option) (*Policy, error) {
+	cfg := config{
+		environment: EnvironmentStable,
+		costLimit:   1000,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	envOpts := []cel.EnvOption{
+		// https://kubernetes.io/docs/reference/using-api/cel/#kubernetes-quantity-library
+		library.Quantity(),
+		// https://kubernetes.io/docs/reference/using-api/cel/#url-library
+		library.URLs(),
+		// https://kubernetes.io/docs/reference/using-api/cel/#regex-library
+		library.Regex(),
+
+		// native types
+		// https://qiita.com/fits/items/def30e3f6fedbd7289f9
+		ext.NativeTypes(
+			reflect.TypeOf(&corev1.PersistentVolumeClaim{}),
+			reflect.TypeOf(&storagev1.StorageClass{}),
+			reflect.TypeOf(&VolumeStats{}),
+			reflect.TypeOf(&Quota{}),
+			reflect.TypeOf(&resource.Quantity{}),
+			ext.ParseStructTag("json"),
+		),
+		// The argument of ObjectType should be equal to reflect.TypeOf(...).String()
+		// ObjectType の引数は reflect.TypeOf(...).String() と同じらしい
+		cel.Variable("pvc", cel.ObjectType("v1.PersistentVolumeClaim")),
+		cel.Variable("sc", cel.ObjectType("v1.StorageClass")),
+		cel.Variable("stats", cel.ObjectType("policy.VolumeStats")),
+		cel.Variable("quota", cel.ObjectType("policy.Quota")),
+
+		// helper functions
+		cel.Function("deny",
+			cel.Overload("deny_string", []*cel.Type{cel.StringType}, cel.IntType, cel.UnaryBinding(denyFunc))),
+		cel.Function("k8sQuantityAsInteger",
+			cel.Overload("k8sQuantityAsInteger_resource.Quantity",
+				[]*cel.Type{cel.ObjectType("resource.Quantity")}, cel.IntType, cel.UnaryBinding(k8sQuantityAsInteger))),
+		cel.Function("remainingStorage",
+			cel.MemberOverload("quota_remainingStorage_sc",
+				[]*cel.Type{cel.ObjectType("policy.Quota"), cel.ObjectType("v1.StorageClass")}, cel.IntType,
+				cel.BinaryBinding(quotaRemainingStorage))),
+		cel.Function("usedStorage",
+			cel.MemberOverload("quota_usedStorage_sc",
+				[]*cel.Type{cel.ObjectType("policy.Quota"), cel.ObjectType("v1.StorageClass")}, cel.IntType,
+				cel.BinaryBinding(quotaUsedStorage))),
+		cel.Function("pvcCount",
+			cel.MemberOverload("quota_pvcCount_sc",
+				[]*cel.Type{cel.ObjectType("policy.Quota"), cel.ObjectType("v1.StorageClass")}, cel.IntType,
+				cel.BinaryBinding(quotaPVCCount))),
+	}
+
+	if cfg.environment.includesBeta() {
+		envOpts = append(envOpts, betaLibraries()...)
+	}
+	envOpts = append(envOpts, cfg.extra...)
+
+	env, err := cel.NewEnv(envOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("NewEnv: %w", err)
+	}
+
+	ast, iss := env.Compile(src)
+	// raise error if the syntax check fails
+	// https://github.com/google/cel-go/blob/master/examples/README.md#examples
+	if err := iss.Err(); err != nil {
+		return nil, fmt.Errorf("Compile: %w", err)
+	}
+	if ast.OutputType() != cel.IntType {
+		return nil, fmt.Errorf("expression must return int value")
+	}
+
+	prg, err := env.Program(ast,
+		// set cost limit
+		cel.CostLimit(cfg.costLimit),
+		// enable cost tracking
+		cel.CostTracking(&library.CostEstimator{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Program: %w", err)
+	}
+
+	return &Policy{ast: ast, prg: prg}, nil
+}
+
+// Evaluate runs the Policy against a PVC/StorageClass/VolumeStats/Quota
+// tuple and normalizes the result into a Decision. A deny() call surfaces
+// as a *DenyError wrapped in err, with Decision.Allowed false. quota may be
+// nil, in which case callers whose expressions reference it will error.
+func (p *Policy) Evaluate(_ context.Context, pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, stats *VolumeStats, quota *Quota) (Decision, error) {
+	out, detail, err := p.prg.Eval(map[string]interface{}{
+		"pvc":   pvc,
+		"sc":    sc,
+		"stats": stats,
+		"quota": quota,
+	})
+
+	var cost uint64
+	if detail != nil && detail.ActualCost() != nil {
+		cost = *detail.ActualCost()
+	}
+
+	if err != nil {
+		if msg, ok := strings.CutPrefix(err.Error(), denySentinel); ok {
+			return Decision{Allowed: false, DenyMessage: msg, Cost: cost}, &DenyError{Message: msg}
+		}
+		return Decision{Cost: cost}, err
+	}
+
+	if out.Type() != cel.IntType {
+		return Decision{Cost: cost}, fmt.Errorf("unexpected result type %s", out.Type())
+	}
+	value, ok := out.Value().(int64)
+	if !ok {
+		return Decision{Cost: cost}, fmt.Errorf("type conversion failed for result %v", out.Value())
+	}
+
+	return Decision{Allowed: true, Score: value, Cost: cost}, nil
+}
+
+func k8sQuantityAsInteger(arg ref.Val) ref.Val {
+	q, ok := arg.Value().(resource.Quantity)
+	if !ok {
+		return types.NewErr("k8sQuantityAsInteger requires resource.Quantity as an argument")
+	}
+	return types.Int(q.Value())
+}
+
+func denyFunc(arg ref.Val) ref.Val {
+	m, ok := arg.(types.String)
+	if !ok {
+		return types.NewErr("deny requires string as an argument")
+	}
+	return types.NewErr("%s%s", denySentinel, string(m))
+}
+
+func quotaRemainingStorage(quotaArg, scArg ref.Val) ref.Val {
+	q, sc, err := quotaAndStorageClass(quotaArg, scArg)
+	if err != nil {
+		return err
+	}
+	return types.Int(q.RemainingStorage(sc.Name))
+}
+
+func quotaUsedStorage(quotaArg, scArg ref.Val) ref.Val {
+	q, sc, err := quotaAndStorageClass(quotaArg, scArg)
+	if err != nil {
+		return err
+	}
+	return types.Int(q.UsedStorage(sc.Name))
+}
+
+func quotaPVCCount(quotaArg, scArg ref.Val) ref.Val {
+	q, sc, err := quotaAndStorageClass(quotaArg, scArg)
+	if err != nil {
+		return err
+	}
+	return types.Int(q.PVCCount(sc.Name))
+}
+
+func quotaAndStorageClass(quotaArg, scArg ref.Val) (*Quota, *storagev1.StorageClass, ref.Val) {
+	q, ok := quotaArg.Value().(*Quota)
+	if !ok {
+		return nil, nil, types.NewErr("quota helpers require Quota as the receiver")
+	}
+	sc, ok := scArg.Value().(*storagev1.StorageClass)
+	if !ok {
+		return nil, nil, types.NewErr("quota helpers require StorageClass as an argument")
+	}
+	return q, sc, nil
+}
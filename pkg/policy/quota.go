@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Quota aggregates a namespace's ResourceQuota hard limits with the
+// PersistentVolumeClaim usage actually observed in the cluster, following
+// the kube quota evaluator's storage resource naming:
+//
+//	requests.storage                                          namespace-wide
+//	<storageclass>.storageclass.storage.k8s.io/requests.storage   per-StorageClass
+//	persistentvolumeclaims                                    namespace-wide
+//	<storageclass>.storageclass.storage.k8s.io/persistentvolumeclaims per-StorageClass
+//
+// Used is computed from a live PVC list rather than trusted from
+// ResourceQuota.Status.Used, which can lag the objects it's meant to cover.
+type Quota struct {
+	hard map[string]resource.Quantity
+	used map[string]resource.Quantity
+}
+
+// NewQuota builds a Quota from a ResourceQuota's hard limits and the
+// current PVCs in its namespace. rq may be nil, meaning no hard limits are
+// configured.
+func NewQuota(rq *corev1.ResourceQuota, pvcs []corev1.PersistentVolumeClaim) *Quota {
+	q := &Quota{
+		hard: map[string]resource.Quantity{},
+		used: map[string]resource.Quantity{},
+	}
+	if rq != nil {
+		for name, qty := range rq.Status.Hard {
+			q.hard[string(name)] = qty
+		}
+	}
+
+	counts := map[string]int64{}
+	for _, pvc := range pvcs {
+		if pvc.Spec.StorageClassName == nil {
+			continue
+		}
+		scName := *pvc.Spec.StorageClassName
+		counts[scName]++
+		counts[""]++
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		addQuantity(q.used, storageClassResourceKey(scName, "requests.storage"), requested)
+		addQuantity(q.used, "requests.storage", requested)
+	}
+	for scName, count := range counts {
+		key := "persistentvolumeclaims"
+		if scName != "" {
+			key = storageClassResourceKey(scName, "persistentvolumeclaims")
+		}
+		c := resource.NewQuantity(count, resource.DecimalSI)
+		q.used[key] = *c
+	}
+
+	return q
+}
+
+func storageClassResourceKey(scName, resourceName string) string {
+	return fmt.Sprintf("%s.storageclass.storage.k8s.io/%s", scName, resourceName)
+}
+
+func addQuantity(m map[string]resource.Quantity, key string, v resource.Quantity) {
+	sum := m[key]
+	sum.Add(v)
+	m[key] = sum
+}
+
+// RemainingStorage returns, in bytes, the hard requests.storage limit for
+// scName minus what's currently used, falling back to the namespace-wide
+// limit if no per-StorageClass limit is set. Zero if no limit is
+// configured at all.
+func (q *Quota) RemainingStorage(scName string) int64 {
+	hard, ok := q.hard[storageClassResourceKey(scName, "requests.storage")]
+	if !ok {
+		hard, ok = q.hard["requests.storage"]
+	}
+	if !ok {
+		return 0
+	}
+	return hard.Value() - q.UsedStorage(scName)
+}
+
+// UsedStorage returns, in bytes, the requests.storage currently consumed
+// by scName, falling back to the namespace-wide total.
+func (q *Quota) UsedStorage(scName string) int64 {
+	if v, ok := q.used[storageClassResourceKey(scName, "requests.storage")]; ok {
+		return v.Value()
+	}
+	v := q.used["requests.storage"]
+	return v.Value()
+}
+
+// PVCCount returns the number of PVCs currently using scName, falling back
+// to the namespace-wide count.
+func (q *Quota) PVCCount(scName string) int64 {
+	if v, ok := q.used[storageClassResourceKey(scName, "persistentvolumeclaims")]; ok {
+		return v.Value()
+	}
+	v := q.used["persistentvolumeclaims"]
+	return v.Value()
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaCELBindings(t *testing.T) {
+	rq := &corev1.ResourceQuota{
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{
+				corev1.ResourceName("fast.storageclass.storage.k8s.io/requests.storage"): resource.MustParse("100Gi"),
+			},
+		},
+	}
+	scName := "fast"
+	pvcs := []corev1.PersistentVolumeClaim{
+		{
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("20Gi"),
+					},
+				},
+			},
+		},
+	}
+	quota := NewQuota(rq, pvcs)
+	sc := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fast"}}
+
+	cases := []struct {
+		name string
+		expr string
+		want int64
+	}{
+		{name: "remainingStorage", expr: "quota.remainingStorage(sc)", want: 100<<30 - 20<<30},
+		{name: "usedStorage", expr: "quota.usedStorage(sc)", want: 20 << 30},
+		{name: "pvcCount", expr: "quota.pvcCount(sc)", want: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Compile(tc.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tc.expr, err)
+			}
+			decision, err := p.Evaluate(context.Background(), &corev1.PersistentVolumeClaim{}, sc, &VolumeStats{}, quota)
+			if err != nil {
+				t.Fatalf("Evaluate(%q): %v", tc.expr, err)
+			}
+			if decision.Score != tc.want {
+				t.Errorf("%s = %d, want %d", tc.expr, decision.Score, tc.want)
+			}
+		})
+	}
+}
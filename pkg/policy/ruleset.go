@@ -0,0 +1,218 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// Severity classifies what a Rule's non-allow result means to the caller:
+// info is advisory, warn should be surfaced but not block, deny should
+// block the resize.
+type Severity string
+
+const (
+	SeverityInfo Severity = "info"
+	SeverityWarn Severity = "warn"
+	SeverityDeny Severity = "deny"
+)
+
+// AggregateMode picks how multiple RuleResults for the same PVC collapse
+// into a single verdict.
+type AggregateMode string
+
+const (
+	// AggregateAnyDeny denies if any deny-severity rule denied.
+	AggregateAnyDeny AggregateMode = "any-deny"
+	// AggregateMaxScore reports the highest score across rules.
+	AggregateMaxScore AggregateMode = "max-score"
+	// AggregateSumScore reports the sum of every rule's score.
+	AggregateSumScore AggregateMode = "sum-score"
+)
+
+// Match selects which PVCs a Rule applies to. A nil field matches
+// everything.
+type Match struct {
+	// StorageClassPattern is a regexp matched against sc.Name.
+	StorageClassPattern string `json:"storageClassPattern,omitempty"`
+	// LabelSelector is matched against pvc.Labels.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// Rule is a single named CEL expression within a RuleSet.
+type Rule struct {
+	Name       string   `json:"name"`
+	Expression string   `json:"expression"`
+	Severity   Severity `json:"severity"`
+	CostLimit  uint64   `json:"costLimit,omitempty"`
+	Match      *Match   `json:"match,omitempty"`
+}
+
+// RuleSet is a policy bundle: every Rule is compiled once at startup and
+// evaluated against every PVC that matches it, analogous to a set of
+// ValidatingAdmissionPolicies.
+type RuleSet struct {
+	Rules     []Rule        `json:"rules"`
+	Aggregate AggregateMode `json:"aggregate,omitempty"`
+}
+
+// LoadRuleSet parses a YAML or JSON document into a RuleSet.
+func LoadRuleSet(raw []byte) (*RuleSet, error) {
+	var rs RuleSet
+	if err := yaml.Unmarshal(raw, &rs); err != nil {
+		return nil, fmt.Errorf("unmarshal rule set: %w", err)
+	}
+	if rs.Aggregate == "" {
+		rs.Aggregate = AggregateAnyDeny
+	}
+	return &rs, nil
+}
+
+// RuleResult is one Rule's outcome for one PVC.
+type RuleResult struct {
+	Name    string
+	Matched bool
+	Verdict Severity
+	Score   int64
+	Cost    uint64
+	Err     error
+}
+
+type compiledRule struct {
+	Rule
+	policy        *Policy
+	scPattern     *regexp.Regexp
+	labelSelector labels.Selector
+}
+
+// CompiledRuleSet is a RuleSet with every rule's expression compiled and
+// every Match selector parsed, ready to Evaluate repeatedly.
+type CompiledRuleSet struct {
+	rules     []compiledRule
+	aggregate AggregateMode
+}
+
+// Compile compiles every rule's expression and parses its Match selectors.
+// opts applies to every rule, except CostLimit which a rule may override
+// via Rule.CostLimit.
+func (rs *RuleSet) Compile(opts ...Option) (*CompiledRuleSet, error) {
+	compiled := make([]compiledRule, 0, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		ruleOpts := opts
+		if rule.CostLimit > 0 {
+			ruleOpts = append(append([]Option{}, opts...), WithCostLimit(rule.CostLimit))
+		}
+
+		p, err := Compile(rule.Expression, ruleOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("compile rule %q: %w", rule.Name, err)
+		}
+
+		cr := compiledRule{Rule: rule, policy: p}
+
+		if rule.Match != nil {
+			if rule.Match.StorageClassPattern != "" {
+				re, err := regexp.Compile(rule.Match.StorageClassPattern)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: storageClassPattern: %w", rule.Name, err)
+				}
+				cr.scPattern = re
+			}
+			if rule.Match.LabelSelector != nil {
+				sel, err := metav1.LabelSelectorAsSelector(rule.Match.LabelSelector)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: labelSelector: %w", rule.Name, err)
+				}
+				cr.labelSelector = sel
+			}
+		}
+
+		compiled = append(compiled, cr)
+	}
+
+	return &CompiledRuleSet{rules: compiled, aggregate: rs.Aggregate}, nil
+}
+
+func (cr compiledRule) matches(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass) bool {
+	if cr.scPattern != nil && !cr.scPattern.MatchString(sc.Name) {
+		return false
+	}
+	if cr.labelSelector != nil && !cr.labelSelector.Matches(labels.Set(pvc.Labels)) {
+		return false
+	}
+	return true
+}
+
+// Evaluate runs every matching rule against the given PVC/StorageClass/
+// VolumeStats/Quota tuple and returns one RuleResult per rule, in RuleSet
+// order. Unmatched rules are still reported, with Matched set to false, so
+// callers can see the full rule coverage.
+func (c *CompiledRuleSet) Evaluate(ctx context.Context, pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, stats *VolumeStats, quota *Quota) []RuleResult {
+	results := make([]RuleResult, 0, len(c.rules))
+	for _, rule := range c.rules {
+		if !rule.matches(pvc, sc) {
+			results = append(results, RuleResult{Name: rule.Name, Matched: false})
+			continue
+		}
+
+		decision, err := rule.policy.Evaluate(ctx, pvc, sc, stats, quota)
+
+		result := RuleResult{Name: rule.Name, Matched: true, Cost: decision.Cost}
+		switch {
+		case err != nil:
+			var denyErr *DenyError
+			if errors.As(err, &denyErr) {
+				result.Verdict = SeverityDeny
+				result.Err = denyErr
+			} else {
+				result.Err = err
+			}
+		case decision.Score != 0:
+			result.Verdict = rule.Severity
+			result.Score = decision.Score
+		default:
+			result.Verdict = ""
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// AggregateResult is the RuleSet-wide verdict computed from a slice of
+// RuleResults according to the RuleSet's AggregateMode.
+type AggregateResult struct {
+	Denied bool
+	Score  int64
+}
+
+// Aggregate folds per-rule results into a single verdict according to the
+// CompiledRuleSet's AggregateMode.
+func (c *CompiledRuleSet) Aggregate(results []RuleResult) AggregateResult {
+	var agg AggregateResult
+	for _, r := range results {
+		if !r.Matched {
+			continue
+		}
+		if r.Verdict == SeverityDeny {
+			agg.Denied = true
+		}
+
+		switch c.aggregate {
+		case AggregateSumScore:
+			agg.Score += r.Score
+		case AggregateMaxScore:
+			if r.Score > agg.Score {
+				agg.Score = r.Score
+			}
+		default: // AggregateAnyDeny
+		}
+	}
+	return agg
+}
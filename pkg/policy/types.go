@@ -0,0 +1,15 @@
+// Package policy compiles and evaluates the CEL expressions that decide
+// whether a PersistentVolumeClaim should be allowed to resize, mirroring
+// the shape of k8s.io/apiserver's cel package: a Policy is compiled once
+// from source and then Evaluated repeatedly against live objects.
+package policy
+
+// VolumeStats carries the per-volume usage figures an expression can read
+// as the `stats` variable, sourced from either the embedded metrics.json
+// fixture or a live kubelet/Prometheus scrape.
+type VolumeStats struct {
+	AvailableBytes     int64 `json:"availableBytes"`
+	CapacityBytes      int64 `json:"capacityBytes"`
+	AvailableInodeSize int64 `json:"availableInodeSize"`
+	CapacityInodeSize  int64 `json:"capacityInodeSize"`
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONLinesReporter is the default machine-readable format: one JSON
+// object per PVC, newline-delimited.
+type JSONLinesReporter struct{}
+
+type jsonLineRule struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	Verdict string `json:"verdict,omitempty"`
+	Score   int64  `json:"score,omitempty"`
+	Cost    uint64 `json:"cost,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type jsonLineResult struct {
+	Namespace    string         `json:"namespace,omitempty"`
+	PVC          string         `json:"pvc"`
+	StorageClass string         `json:"storageClass"`
+	Denied       bool           `json:"denied"`
+	Score        int64          `json:"score"`
+	Rules        []jsonLineRule `json:"rules"`
+}
+
+func (r *JSONLinesReporter) Report(w io.Writer, results []PVCResult) error {
+	enc := json.NewEncoder(w)
+	for _, res := range results {
+		line := jsonLineResult{
+			Namespace:    res.Namespace,
+			PVC:          res.PVC,
+			StorageClass: res.StorageClassName,
+			Denied:       res.Aggregate.Denied,
+			Score:        res.Aggregate.Score,
+		}
+		for _, rule := range res.Rules {
+			jr := jsonLineRule{
+				Name:    rule.Name,
+				Matched: rule.Matched,
+				Verdict: string(rule.Verdict),
+				Score:   rule.Score,
+				Cost:    rule.Cost,
+			}
+			if rule.Err != nil {
+				jr.Error = rule.Err.Error()
+			}
+			line.Rules = append(line.Rules, jr)
+		}
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("encode %s: %w", res.PVC, err)
+		}
+	}
+	return nil
+}
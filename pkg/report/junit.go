@@ -0,0 +1,69 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// JUnitReporter renders one testsuite per PVC with one testcase per matched
+// rule, with a <failure> element when a rule denied or failed to evaluate.
+type JUnitReporter struct{}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (r *JUnitReporter) Report(w io.Writer, results []PVCResult) error {
+	suites := junitTestSuites{}
+
+	for _, res := range results {
+		suite := junitTestSuite{Name: res.PVC}
+		for _, rule := range res.Rules {
+			if !rule.Matched {
+				continue
+			}
+			tc := junitTestCase{Name: fmt.Sprintf("%s/%s", res.PVC, rule.Name)}
+
+			switch {
+			case rule.Err != nil:
+				tc.Failure = &junitFailure{Message: "error", Text: rule.Err.Error()}
+				suite.Failures++
+			case rule.Verdict == policy.SeverityDeny:
+				tc.Failure = &junitFailure{Message: "deny", Text: fmt.Sprintf("score=%d", rule.Score)}
+				suite.Failures++
+			}
+
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(suites)
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// PrometheusReporter keeps the latest verdict per (pvc, sc, rule) in a
+// registry that ListenAndServe exposes on /metrics, for the controller or
+// CI runner to be scraped rather than parsed from logs.
+type PrometheusReporter struct {
+	registry *prometheus.Registry
+	verdict  *prometheus.GaugeVec
+	cost     *prometheus.HistogramVec
+}
+
+// NewPrometheusReporter builds a PrometheusReporter with its own registry,
+// so embedding it doesn't collide with the default global one.
+func NewPrometheusReporter() *PrometheusReporter {
+	verdict := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "trial_cel_pvc_verdict",
+		Help: "1 if the rule denied the PVC, 0 otherwise.",
+	}, []string{"pvc", "sc", "rule"})
+
+	cost := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "trial_cel_eval_cost",
+		Help:    "CEL evaluation cost per rule.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"pvc", "sc", "rule"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(verdict, cost)
+
+	return &PrometheusReporter{registry: registry, verdict: verdict, cost: cost}
+}
+
+// Report updates the verdict gauge and cost histogram for every matched
+// rule. w is unused; metrics are only readable via ListenAndServe.
+func (r *PrometheusReporter) Report(_ io.Writer, results []PVCResult) error {
+	for _, res := range results {
+		for _, rule := range res.Rules {
+			if !rule.Matched {
+				continue
+			}
+			labels := prometheus.Labels{"pvc": res.PVC, "sc": res.StorageClassName, "rule": rule.Name}
+
+			denied := 0.0
+			if rule.Verdict == policy.SeverityDeny {
+				denied = 1.0
+			}
+			r.verdict.With(labels).Set(denied)
+			r.cost.With(labels).Observe(float64(rule.Cost))
+		}
+	}
+	return nil
+}
+
+// ListenAndServe serves /metrics on addr, blocking until the server exits.
+func (r *PrometheusReporter) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{}))
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		return fmt.Errorf("serve %s: %w", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package report renders RuleSet evaluation results for consumption by CI
+// and observability pipelines, instead of the one-shot tool's previous
+// log.Printf sinks.
+package report
+
+import (
+	"io"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// PVCResult bundles every rule's outcome for a single PVC, enough context
+// for any Reporter to render a line, a testcase, or a metric sample.
+type PVCResult struct {
+	Namespace        string
+	PVC              string
+	StorageClassName string
+	Rules            []policy.RuleResult
+	Aggregate        policy.AggregateResult
+}
+
+// Reporter renders a batch of PVCResults. Implementations that need a
+// live process (e.g. Prometheus) still satisfy Report by updating their
+// internal state; callers serve it separately.
+type Reporter interface {
+	Report(w io.Writer, results []PVCResult) error
+}
+
+// Format selects a Reporter via --report-format.
+type Format string
+
+const (
+	FormatJSONLines  Format = "json-lines"
+	FormatJUnit      Format = "junit"
+	FormatSARIF      Format = "sarif"
+	FormatPrometheus Format = "prometheus"
+)
+
+// New returns the Reporter for a --report-format value.
+func New(format Format) (Reporter, error) {
+	switch format {
+	case "", FormatJSONLines:
+		return &JSONLinesReporter{}, nil
+	case FormatJUnit:
+		return &JUnitReporter{}, nil
+	case FormatSARIF:
+		return &SARIFReporter{}, nil
+	case FormatPrometheus:
+		return NewPrometheusReporter(), nil
+	default:
+		return nil, &UnknownFormatError{Format: format}
+	}
+}
+
+// UnknownFormatError is returned by New for an unrecognized --report-format.
+type UnknownFormatError struct {
+	Format Format
+}
+
+func (e *UnknownFormatError) Error() string {
+	return "unknown report format: " + string(e.Format)
+}
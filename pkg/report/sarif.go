@@ -0,0 +1,129 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// SARIFReporter renders results as a SARIF 2.1.0 log: each PVC is an
+// artifact, each rule is a SARIF rule, and a denied rule becomes a result
+// at "error" level (info/warn map to "note"/"warning").
+type SARIFReporter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func (r *SARIFReporter) Report(w io.Writer, results []PVCResult) error {
+	ruleIDs := map[string]bool{}
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "trial-cel"}}}
+
+	for _, res := range results {
+		for _, rule := range res.Rules {
+			if !rule.Matched {
+				continue
+			}
+			if !ruleIDs[rule.Name] {
+				ruleIDs[rule.Name] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule.Name})
+			}
+
+			level := sarifLevel(rule)
+			if level == "" {
+				continue
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID: rule.Name,
+				Level:  level,
+				Message: sarifMessage{
+					Text: sarifMessageText(rule),
+				},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: res.PVC},
+					},
+				}},
+			})
+		}
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifLevel maps a RuleResult to a SARIF result level. A rule with no
+// verdict and no error (it allowed the PVC) produces no SARIF result at all.
+func sarifLevel(rule policy.RuleResult) string {
+	switch {
+	case rule.Err != nil:
+		return "error"
+	case rule.Verdict == policy.SeverityDeny:
+		return "error"
+	case rule.Verdict == policy.SeverityWarn:
+		return "warning"
+	case rule.Verdict == policy.SeverityInfo:
+		return "note"
+	default:
+		return ""
+	}
+}
+
+func sarifMessageText(rule policy.RuleResult) string {
+	if rule.Err != nil {
+		return rule.Err.Error()
+	}
+	return fmt.Sprintf("verdict=%s score=%d", rule.Verdict, rule.Score)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	storagev1listers "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+	"github.com/llamerada-jp/trial-cel/pkg/policy/library"
+	"github.com/llamerada-jp/trial-cel/pkg/report"
+)
+
+const (
+	// annotationPrefix namespaces every status annotation the controller
+	// writes back onto a PVC after evaluating it.
+	annotationPrefix  = "trial-cel.llamerada.jp/"
+	annotationVerdict = annotationPrefix + "verdict"
+	annotationScore   = annotationPrefix + "score"
+	annotationReason  = annotationPrefix + "reason"
+	annotationCost    = annotationPrefix + "cost"
+
+	controllerName = "trial-cel-controller"
+)
+
+// runController starts an informer-driven loop, mirroring the
+// capability-controller pattern: watch PVCs and StorageClasses, and on
+// every change (or resync) re-evaluate the embedded RuleSet with fresh
+// VolumeStats, recording the verdict as an Event and a status annotation
+// on the PVC.
+func runController(args []string) {
+	fs := flag.NewFlagSet("controller", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig; empty uses in-cluster config")
+	metricsSource := fs.String("metrics-source", "file://embedded",
+		"where to read VolumeStats from: file://embedded, file://<path>, http(s)://<url>, or kubelet://<node>")
+	resync := fs.Duration("resync-period", 5*time.Minute, "informer full resync period")
+	reportFormat := fs.String("report-format", string(report.FormatPrometheus),
+		"output format: json-lines, junit, sarif, or prometheus; prometheus also serves --metrics-addr")
+	metricsAddr := fs.String("metrics-addr", ":9090",
+		"address to serve /metrics on when --report-format=prometheus")
+	fs.Parse(args)
+
+	rs, err := policy.LoadRuleSet(ruleSetYaml)
+	if err != nil {
+		log.Fatal(err)
+	}
+	compiled, err := rs.Compile(policy.WithEnvOption(library.StoragePolicy()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var quotaHard corev1.ResourceQuota
+	if err := yaml.Unmarshal(quotasYaml, &quotaHard); err != nil {
+		log.Fatal("Unmarshal quotas:", err)
+	}
+
+	provider, err := NewStatsProvider(*metricsSource)
+	if err != nil {
+		log.Fatal("NewStatsProvider:", err)
+	}
+
+	reporter, err := report.New(report.Format(*reportFormat))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if promReporter, ok := reporter.(*report.PrometheusReporter); ok {
+		log.Printf("serving /metrics on %s", *metricsAddr)
+		go func() {
+			log.Fatal(promReporter.ListenAndServe(*metricsAddr))
+		}()
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		log.Fatal("BuildConfig:", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal("NewForConfig:", err)
+	}
+
+	factory := informers.NewSharedInformerFactory(clientset, *resync)
+	pvcInformer := factory.Core().V1().PersistentVolumeClaims()
+	scInformer := factory.Storage().V1().StorageClasses()
+
+	c := &controller{
+		clientset: clientset,
+		recorder:  newEventRecorder(clientset),
+		ruleSet:   compiled,
+		provider:  provider,
+		pvcLister: pvcInformer.Lister(),
+		scLister:  scInformer.Lister(),
+		quotaHard: &quotaHard,
+		reporter:  reporter,
+	}
+
+	if _, err := pvcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.onPVCChange(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.onPVCChange(obj) },
+	}); err != nil {
+		log.Fatal("AddEventHandler pvc:", err)
+	}
+	// A StorageClass change can flip the verdict for every PVC that uses it,
+	// so re-evaluate those PVCs too instead of waiting for the next resync.
+	if _, err := scInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.onStorageClassChange(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.onStorageClassChange(obj) },
+	}); err != nil {
+		log.Fatal("AddEventHandler sc:", err)
+	}
+
+	ctx := context.Background()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	log.Printf("%s started, resync every %s", controllerName, *resync)
+	<-ctx.Done()
+}
+
+// controller holds the dependencies needed to re-evaluate a PVC: the
+// compiled RuleSet, the live StatsProvider, and enough client-go plumbing
+// to look up related objects and report back.
+type controller struct {
+	clientset kubernetes.Interface
+	recorder  record.EventRecorder
+	ruleSet   *policy.CompiledRuleSet
+	provider  StatsProvider
+	pvcLister corev1listers.PersistentVolumeClaimLister
+	scLister  storagev1listers.StorageClassLister
+	// quotaHard is the ResourceQuota loaded at startup; only its
+	// Status.Hard limits are used, since Used is recomputed live from
+	// pvcLister on every reconcile.
+	quotaHard *corev1.ResourceQuota
+	// reporter renders every reconcile's results through the same Reporter
+	// the one-shot tool uses; for --report-format=prometheus this is what
+	// keeps the /metrics gauges current as PVCs change.
+	reporter report.Reporter
+}
+
+// currentQuota lists every PVC visible to the informer cache and combines
+// it with the controller's hard quota limits, mirroring runOnce's
+// policy.NewQuota(&rq, pvcs.Items) but kept fresh across reconciles instead
+// of computed once.
+func (c *controller) currentQuota() (*policy.Quota, error) {
+	pvcs, err := c.pvcLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("list PVCs: %w", err)
+	}
+	items := make([]corev1.PersistentVolumeClaim, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		items = append(items, *pvc)
+	}
+	return policy.NewQuota(c.quotaHard, items), nil
+}
+
+func (c *controller) onPVCChange(obj interface{}) {
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return
+	}
+	c.reconcile(pvc)
+}
+
+func (c *controller) onStorageClassChange(obj interface{}) {
+	sc, ok := obj.(*storagev1.StorageClass)
+	if !ok {
+		return
+	}
+	pvcs, err := c.pvcLister.List(labels.Everything())
+	if err != nil {
+		log.Printf("list PVCs for StorageClass %s: %v", sc.Name, err)
+		return
+	}
+	for _, pvc := range pvcs {
+		if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == sc.Name {
+			c.reconcile(pvc)
+		}
+	}
+}
+
+// reconcile evaluates every RuleSet rule for a single PVC, records an Event
+// for each matched rule that produced a verdict or an error, and writes the
+// aggregate verdict as a set of status annotations.
+func (c *controller) reconcile(pvc *corev1.PersistentVolumeClaim) {
+	if pvc.Spec.StorageClassName == nil {
+		return
+	}
+	sc, err := c.scLister.Get(*pvc.Spec.StorageClassName)
+	if err != nil {
+		log.Printf("StorageClass %s not found for %s: %v", *pvc.Spec.StorageClassName, pvc.Name, err)
+		return
+	}
+
+	stats, err := c.provider.Stats(context.Background())
+	if err != nil {
+		log.Printf("Stats: %v", err)
+		return
+	}
+	stat, ok := stats[pvc.Name]
+	if !ok {
+		log.Printf("VolumeStats not found for %s", pvc.Name)
+		return
+	}
+
+	quota, err := c.currentQuota()
+	if err != nil {
+		log.Printf("currentQuota: %v", err)
+		return
+	}
+
+	results := c.ruleSet.Evaluate(context.Background(), pvc, sc, stat, quota)
+	agg := c.ruleSet.Aggregate(results)
+
+	if err := c.reporter.Report(io.Discard, []report.PVCResult{{
+		Namespace:        pvc.Namespace,
+		PVC:              pvc.Name,
+		StorageClassName: sc.Name,
+		Rules:            results,
+		Aggregate:        agg,
+	}}); err != nil {
+		log.Printf("report %s: %v", pvc.Name, err)
+	}
+
+	verdict := "allow"
+	eventType := corev1.EventTypeNormal
+	if agg.Denied {
+		verdict = "deny"
+		eventType = corev1.EventTypeWarning
+	}
+
+	for _, r := range results {
+		if !r.Matched {
+			continue
+		}
+		if r.Err != nil {
+			c.recorder.Eventf(pvc, corev1.EventTypeWarning, "PolicyRuleError", "rule=%s: %v", r.Name, r.Err)
+			continue
+		}
+		if r.Verdict != "" {
+			c.recorder.Eventf(pvc, eventType, "PolicyEvaluated", "rule=%s verdict=%s score=%d cost=%d", r.Name, r.Verdict, r.Score, r.Cost)
+		}
+	}
+
+	if err := c.patchAnnotations(pvc, verdict, agg.Score, aggregateReason(results), aggregateCost(results)); err != nil {
+		log.Printf("patch annotations for %s: %v", pvc.Name, err)
+	}
+}
+
+// aggregateReason joins every matched rule's deny/warn reason (or
+// evaluation error) into a single human-readable string, in RuleSet order,
+// for the /reason status annotation.
+func aggregateReason(results []policy.RuleResult) string {
+	var reasons []string
+	for _, r := range results {
+		switch {
+		case !r.Matched:
+			continue
+		case r.Err != nil:
+			reasons = append(reasons, fmt.Sprintf("%s: %v", r.Name, r.Err))
+		case r.Verdict == policy.SeverityDeny || r.Verdict == policy.SeverityWarn:
+			reasons = append(reasons, fmt.Sprintf("%s: %s", r.Name, r.Verdict))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// aggregateCost sums every matched rule's evaluation cost, for the /cost
+// status annotation.
+func aggregateCost(results []policy.RuleResult) uint64 {
+	var total uint64
+	for _, r := range results {
+		if r.Matched {
+			total += r.Cost
+		}
+	}
+	return total
+}
+
+func (c *controller) patchAnnotations(pvc *corev1.PersistentVolumeClaim, verdict string, score int64, reason string, cost uint64) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				annotationVerdict: verdict,
+				annotationScore:   fmt.Sprintf("%d", score),
+				annotationReason:  reason,
+				annotationCost:    fmt.Sprintf("%d", cost),
+			},
+		},
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = c.clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(
+		context.Background(), pvc.Name, types.MergePatchType, raw, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func newEventRecorder(clientset kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: clientset.CoreV1().Events(""),
+	})
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerName})
+}
@@ -1,109 +1,69 @@
 package main
 
 import (
+	"context"
 	_ "embed"
-	"encoding/json"
+	"flag"
 	"log"
-	"reflect"
-	"strings"
+	"os"
 
-	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/common/types"
-	"github.com/google/cel-go/common/types/ref"
-	"github.com/google/cel-go/ext"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
-	"k8s.io/apiserver/pkg/cel/library"
 	"sigs.k8s.io/yaml"
-)
 
-const (
-	denyPrefix = "deny:"
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+	"github.com/llamerada-jp/trial-cel/pkg/policy/library"
+	"github.com/llamerada-jp/trial-cel/pkg/report"
 )
 
 var (
-	//go:embed expression.cel
-	expression string
+	//go:embed ruleset.yaml
+	ruleSetYaml []byte
 	//go:embed metrics.json
 	metricsJson []byte
 	//go:embed pvcs.yaml
 	pvcsYaml []byte
 	//go:embed scs.yaml
 	scsYaml []byte
+	//go:embed quotas.yaml
+	quotasYaml []byte
 )
 
-type VolumeStats struct {
-	AvailableBytes     int64 `json:"availableBytes"`
-	CapacityBytes      int64 `json:"capacityBytes"`
-	AvailableInodeSize int64 `json:"availableInodeSize"`
-	CapacityInodeSize  int64 `json:"capacityInodeSize"`
-}
-
+// main dispatches to the one-shot evaluator (default) or the "controller"
+// subcommand that watches PVCs/StorageClasses and re-evaluates on change.
 func main() {
-	// setup CEL
-	env, err := cel.NewEnv(
-		// https://kubernetes.io/docs/reference/using-api/cel/#kubernetes-quantity-library
-		library.Quantity(),
-
-		// defined types by protobuf
-		/*
-			Kubernetes API types are defined by protobuf but are not protobuf generated types.
-			K8s の API は protobuf で定義されているが、protobuf 生成された型ではないらしい。エラーになる。
-			https://christina04.hatenablog.com/entry/use-custom-variable-in-cel
-			https://codelabs.developers.google.com/codelabs/cel-go?hl=ja#5
-			https://github.com/kubernetes/api/blob/release-1.32/core/v1/generated.proto#L2997
-			https://github.com/kubernetes/api/blob/release-1.32/storage/v1/generated.proto#L392
-			cel.Types(
-				&corev1.PersistentVolumeClaim{},
-				&storagev1.StorageClass{},
-			),
-		*/
-
-		// native types
-		// https://qiita.com/fits/items/def30e3f6fedbd7289f9
-		ext.NativeTypes(
-			reflect.TypeOf(&corev1.PersistentVolumeClaim{}),
-			reflect.TypeOf(&storagev1.StorageClass{}),
-			reflect.TypeOf(&VolumeStats{}),
-			reflect.TypeOf(&resource.Quantity{}),
-			ext.ParseStructTag("json"),
-		),
-		// The argument of ObjectType should be equal to reflect.TypeOf(...).String()
-		// ObjectType の引数は reflect.TypeOf(...).String() と同じらしい
-		cel.Variable("pvc", cel.ObjectType("v1.PersistentVolumeClaim")),
-		cel.Variable("sc", cel.ObjectType("v1.StorageClass")),
-		cel.Variable("stats", cel.ObjectType("main.VolumeStats")),
-
-		// helper functions
-		cel.Function("deny",
-			cel.Overload("deny_string", []*cel.Type{cel.StringType}, cel.IntType, cel.UnaryBinding(deny))),
-		cel.Function("k8sQuantityAsInteger",
-			cel.Overload("k8sQuantityAsInteger_resource.Quantity",
-				[]*cel.Type{cel.ObjectType("resource.Quantity")}, cel.IntType, cel.UnaryBinding(k8sQuantityAsInteger))),
-	)
-	if err != nil {
-		log.Fatal("NewEnv:", err)
+	if len(os.Args) > 1 && os.Args[1] == "controller" {
+		runController(os.Args[2:])
+		return
 	}
+	runOnce(os.Args[1:])
+}
 
-	ast, iss := env.Compile(expression)
-	// raise error if the syntax check fails
-	// https://github.com/google/cel-go/blob/master/examples/README.md#examples
-	if err := iss.Err(); err != nil {
-		log.Fatal("Compile:", err)
+// runOnce evaluates every rule in the embedded RuleSet against every PVC in
+// pvcs.yaml, matched against scs.yaml and the metrics source, rendering the
+// results with the Reporter selected by --report-format.
+func runOnce(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	metricsSource := fs.String("metrics-source", "file://embedded",
+		"where to read VolumeStats from: file://embedded, file://<path>, http(s)://<url>, or kubelet://<node>")
+	reportFormat := fs.String("report-format", string(report.FormatJSONLines),
+		"output format: json-lines, junit, sarif, or prometheus")
+	metricsAddr := fs.String("metrics-addr", ":9090",
+		"address to serve /metrics on when --report-format=prometheus")
+	fs.Parse(args)
+
+	rs, err := policy.LoadRuleSet(ruleSetYaml)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if ast.OutputType() != cel.IntType {
-		log.Fatal("expression must return int value")
+	compiled, err := rs.Compile(policy.WithEnvOption(library.StoragePolicy()))
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	prg, err := env.Program(ast,
-		// set cost limit
-		cel.CostLimit(1000),
-		// enable cost tracking
-		cel.CostTracking(&library.CostEstimator{}),
-	)
+	reporter, err := report.New(report.Format(*reportFormat))
 	if err != nil {
-		log.Fatal("Program:", err)
+		log.Fatal(err)
 	}
 
 	// read StorageClass & PersistentVolumeClaim
@@ -120,12 +80,24 @@ func main() {
 		log.Fatal("Unmarshal pvcs:", err)
 	}
 
+	// read ResourceQuota and aggregate current PVC usage per StorageClass
+	var rq corev1.ResourceQuota
+	if err := yaml.Unmarshal(quotasYaml, &rq); err != nil {
+		log.Fatal("Unmarshal quotas:", err)
+	}
+	quota := policy.NewQuota(&rq, pvcs.Items)
+
 	// read metrics
-	var stats map[string]*VolumeStats
-	if err := json.Unmarshal(metricsJson, &stats); err != nil {
-		log.Fatal("Unmarshal metrics:", err)
+	provider, err := NewStatsProvider(*metricsSource)
+	if err != nil {
+		log.Fatal("NewStatsProvider:", err)
+	}
+	stats, err := provider.Stats(context.Background())
+	if err != nil {
+		log.Fatal("Stats:", err)
 	}
 
+	var results []report.PVCResult
 	for _, pvc := range pvcs.Items {
 		// skip if sc or stats not found
 		sc, ok := scsMap[*pvc.Spec.StorageClassName]
@@ -139,47 +111,22 @@ func main() {
 			continue
 		}
 
-		// evaluate!
-		out, detail, err := prg.Eval(map[string]interface{}{
-			"pvc":   &pvc,
-			"sc":    sc,
-			"stats": stat,
+		rules := compiled.Evaluate(context.Background(), &pvc, sc, stat, quota)
+		results = append(results, report.PVCResult{
+			Namespace:        pvc.Namespace,
+			PVC:              pvc.Name,
+			StorageClassName: sc.Name,
+			Rules:            rules,
+			Aggregate:        compiled.Aggregate(rules),
 		})
-		if err != nil {
-			// もっとマシな判定方法があるはず
-			if strings.HasPrefix(err.Error(), denyPrefix) {
-				log.Printf("%s: %s", pvc.Name, strings.TrimPrefix(err.Error(), denyPrefix))
-			} else {
-				log.Printf("Error evaluating %s: %v", pvc.Name, err)
-			}
-			continue
-		}
-
-		// print result
-		if out.Type() == cel.IntType {
-			value, ok := out.Value().(int64)
-			if !ok {
-				log.Fatal("Type conversion failed")
-			}
-			log.Printf("%s res=%d, cost=%d", pvc.Name, value, *detail.ActualCost())
-		} else {
-			log.Printf("Unexpected type %s for %s", out.Type(), pvc.Name)
-		}
 	}
-}
 
-func k8sQuantityAsInteger(arg ref.Val) ref.Val {
-	q, ok := arg.Value().(resource.Quantity)
-	if !ok {
-		return types.NewErr("helperQuantityAsInt requires resource.Quantity as an argument")
+	if err := reporter.Report(os.Stdout, results); err != nil {
+		log.Fatal("Report:", err)
 	}
-	return types.Int(q.Value())
-}
 
-func deny(arg ref.Val) ref.Val {
-	m, ok := arg.(types.String)
-	if !ok {
-		return types.NewErr("deny requires string as an argument")
+	if promReporter, ok := reporter.(*report.PrometheusReporter); ok {
+		log.Printf("serving /metrics on %s", *metricsAddr)
+		log.Fatal(promReporter.ListenAndServe(*metricsAddr))
 	}
-	return types.NewErr("%s%s", denyPrefix, string(m))
 }
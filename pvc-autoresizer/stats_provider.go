@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/llamerada-jp/trial-cel/pkg/policy"
+)
+
+// Prometheus series names joined by the persistentvolumeclaim label to build
+// a VolumeStats entry, as exposed by kubelet /metrics/resource and
+// kube-state-metrics.
+const (
+	metricAvailableBytes  = "kubelet_volume_stats_available_bytes"
+	metricCapacityBytes   = "kubelet_volume_stats_capacity_bytes"
+	metricAvailableInodes = "kubelet_volume_stats_inodes_free"
+	metricCapacityInodes  = "kubelet_volume_stats_inodes"
+
+	pvcLabel = "persistentvolumeclaim"
+)
+
+// StatsProvider abstracts where VolumeStats come from so callers, and tests,
+// don't need to know whether they're reading the embedded fixture or
+// scraping a live endpoint.
+type StatsProvider interface {
+	// Stats returns VolumeStats keyed by PersistentVolumeClaim name.
+	Stats(ctx context.Context) (map[string]*policy.VolumeStats, error)
+}
+
+// NewStatsProvider builds a StatsProvider from a --metrics-source value.
+//
+// Supported schemes:
+//
+//	file://embedded   use the metrics.json fixture embedded in the binary
+//	file://<path>     read a metrics.json-shaped file from disk
+//	http(s)://<url>   scrape a Prometheus text-exposition endpoint
+//	kubelet://<node>  scrape https://<node>:10250/metrics/resource
+func NewStatsProvider(source string) (StatsProvider, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse metrics-source %q: %w", source, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := u.Host + u.Path
+		if path == "embedded" || path == "" {
+			return &FileStatsProvider{useEmbedded: true}, nil
+		}
+		return &FileStatsProvider{path: path}, nil
+	case "http", "https":
+		return &HTTPStatsProvider{endpoint: source}, nil
+	case "kubelet":
+		return &KubeletStatsProvider{node: u.Host}, nil
+	default:
+		return nil, fmt.Errorf("unsupported metrics-source scheme %q", u.Scheme)
+	}
+}
+
+// FileStatsProvider serves VolumeStats from the embedded metrics.json
+// fixture or from an on-disk file of the same shape.
+type FileStatsProvider struct {
+	path        string
+	useEmbedded bool
+}
+
+func (p *FileStatsProvider) Stats(_ context.Context) (map[string]*policy.VolumeStats, error) {
+	raw := metricsJson
+	if !p.useEmbedded {
+		b, err := os.ReadFile(p.path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", p.path, err)
+		}
+		raw = b
+	}
+
+	var stats map[string]*policy.VolumeStats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		return nil, fmt.Errorf("unmarshal metrics: %w", err)
+	}
+	return stats, nil
+}
+
+// HTTPStatsProvider scrapes a Prometheus text-exposition endpoint, such as
+// kube-state-metrics, and joins the kubelet_volume_stats_* series by the
+// persistentvolumeclaim label.
+type HTTPStatsProvider struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (p *HTTPStatsProvider) Stats(ctx context.Context) (map[string]*policy.VolumeStats, error) {
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request for %s: %w", p.endpoint, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scrape %s: %w", p.endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape %s: unexpected status %s", p.endpoint, resp.Status)
+	}
+
+	return parseVolumeStatsMetrics(resp.Body)
+}
+
+// KubeletStatsProvider scrapes a node's kubelet /metrics/resource endpoint
+// directly, authenticating with the pod's service account token when run
+// in-cluster.
+type KubeletStatsProvider struct {
+	node   string
+	client *http.Client
+}
+
+const (
+	kubeletPort = 10250
+	saTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+func (p *KubeletStatsProvider) Stats(ctx context.Context) (map[string]*policy.VolumeStats, error) {
+	endpoint := fmt.Sprintf("https://%s:%d/metrics/resource", p.node, kubeletPort)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request for %s: %w", endpoint, err)
+	}
+	if token, err := os.ReadFile(saTokenPath); err == nil {
+		req.Header.Set("Authorization", "Bearer "+string(token))
+	}
+
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scrape %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape %s: unexpected status %s", endpoint, resp.Status)
+	}
+
+	return parseVolumeStatsMetrics(resp.Body)
+}
+
+// parseVolumeStatsMetrics decodes a Prometheus text-exposition body and
+// joins the four kubelet_volume_stats_* series by their persistentvolumeclaim
+// label into a VolumeStats map.
+func parseVolumeStatsMetrics(r io.Reader) (map[string]*policy.VolumeStats, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse metrics: %w", err)
+	}
+
+	stats := make(map[string]*policy.VolumeStats)
+	ensure := func(pvc string) *policy.VolumeStats {
+		s, ok := stats[pvc]
+		if !ok {
+			s = &policy.VolumeStats{}
+			stats[pvc] = s
+		}
+		return s
+	}
+
+	assign := func(name string, set func(s *policy.VolumeStats, v int64)) {
+		family, ok := families[name]
+		if !ok {
+			return
+		}
+		for _, m := range family.GetMetric() {
+			pvc := pvcLabelValue(m)
+			if pvc == "" {
+				continue
+			}
+			set(ensure(pvc), int64(m.GetGauge().GetValue()))
+		}
+	}
+
+	assign(metricAvailableBytes, func(s *policy.VolumeStats, v int64) { s.AvailableBytes = v })
+	assign(metricCapacityBytes, func(s *policy.VolumeStats, v int64) { s.CapacityBytes = v })
+	assign(metricAvailableInodes, func(s *policy.VolumeStats, v int64) { s.AvailableInodeSize = v })
+	assign(metricCapacityInodes, func(s *policy.VolumeStats, v int64) { s.CapacityInodeSize = v })
+
+	return stats, nil
+}
+
+func pvcLabelValue(m *dto.Metric) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == pvcLabel {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeStatsMetrics(t *testing.T) {
+	const body = `
+# HELP kubelet_volume_stats_available_bytes Number of available bytes in the volume
+# TYPE kubelet_volume_stats_available_bytes gauge
+kubelet_volume_stats_available_bytes{persistentvolumeclaim="data-0",namespace="default"} 100
+# HELP kubelet_volume_stats_capacity_bytes Capacity in bytes of the volume
+# TYPE kubelet_volume_stats_capacity_bytes gauge
+kubelet_volume_stats_capacity_bytes{persistentvolumeclaim="data-0",namespace="default"} 1000
+# HELP kubelet_volume_stats_inodes_free Number of free inodes
+# TYPE kubelet_volume_stats_inodes_free gauge
+kubelet_volume_stats_inodes_free{persistentvolumeclaim="data-0",namespace="default"} 10
+# HELP kubelet_volume_stats_inodes Maximum number of inodes in the volume
+# TYPE kubelet_volume_stats_inodes gauge
+kubelet_volume_stats_inodes{persistentvolumeclaim="data-0",namespace="default"} 100
+`
+
+	stats, err := parseVolumeStatsMetrics(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseVolumeStatsMetrics: %v", err)
+	}
+
+	s, ok := stats["data-0"]
+	if !ok {
+		t.Fatalf("expected stats for data-0, got %v", stats)
+	}
+	if s.AvailableBytes != 100 || s.CapacityBytes != 1000 || s.AvailableInodeSize != 10 || s.CapacityInodeSize != 100 {
+		t.Errorf("unexpected VolumeStats: %+v", s)
+	}
+}
+
+func TestParseVolumeStatsMetrics_MissingLabel(t *testing.T) {
+	const body = `
+# HELP kubelet_volume_stats_available_bytes Number of available bytes in the volume
+# TYPE kubelet_volume_stats_available_bytes gauge
+kubelet_volume_stats_available_bytes{namespace="default"} 100
+`
+
+	stats, err := parseVolumeStatsMetrics(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseVolumeStatsMetrics: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("expected no entries without a persistentvolumeclaim label, got %v", stats)
+	}
+}
+
+func TestNewStatsProvider(t *testing.T) {
+	cases := []struct {
+		source  string
+		want    interface{}
+		wantErr bool
+	}{
+		{source: "file://embedded", want: &FileStatsProvider{}},
+		{source: "file:///tmp/metrics.json", want: &FileStatsProvider{}},
+		{source: "http://example.invalid/metrics", want: &HTTPStatsProvider{}},
+		{source: "https://example.invalid/metrics", want: &HTTPStatsProvider{}},
+		{source: "kubelet://node-1", want: &KubeletStatsProvider{}},
+		{source: "ftp://nope", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := NewStatsProvider(tc.source)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("NewStatsProvider(%q): expected error, got none", tc.source)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewStatsProvider(%q): %v", tc.source, err)
+			continue
+		}
+		switch tc.want.(type) {
+		case *FileStatsProvider:
+			if _, ok := got.(*FileStatsProvider); !ok {
+				t.Errorf("NewStatsProvider(%q): got %T, want *FileStatsProvider", tc.source, got)
+			}
+		case *HTTPStatsProvider:
+			if _, ok := got.(*HTTPStatsProvider); !ok {
+				t.Errorf("NewStatsProvider(%q): got %T, want *HTTPStatsProvider", tc.source, got)
+			}
+		case *KubeletStatsProvider:
+			if _, ok := got.(*KubeletStatsProvider); !ok {
+				t.Errorf("NewStatsProvider(%q): got %T, want *KubeletStatsProvider", tc.source, got)
+			}
+		}
+	}
+}